@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelayGrowsExponentially(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt := int64(1); attempt < 5; attempt++ {
+		d := backoffDelay(base, attempt)
+		min := base * time.Duration(1<<uint(attempt-1))
+		max := min + min/2
+		if d < min || d > max {
+			t.Errorf("attempt %d: backoffDelay = %v, want in [%v, %v]", attempt, d, min, max)
+		}
+	}
+}
+
+func TestBackoffDelayCapsAtMaxBackoff(t *testing.T) {
+	d := backoffDelay(time.Second, 1000)
+	if d < maxBackoff || d > maxBackoff+maxBackoff/2 {
+		t.Errorf("backoffDelay with huge attempt = %v, want capped near maxBackoff (%v)", d, maxBackoff)
+	}
+}
+
+func TestBackoffDelayTreatsNonPositiveAttemptAsFirst(t *testing.T) {
+	d := backoffDelay(100*time.Millisecond, 0)
+	if d < 100*time.Millisecond || d > 150*time.Millisecond {
+		t.Errorf("backoffDelay(base, 0) = %v, want same range as attempt 1", d)
+	}
+}
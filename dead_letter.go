@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
 	"os/signal"
 	"sort"
@@ -13,6 +15,10 @@ import (
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/lensesio/tableprinter"
 	"github.com/streadway/amqp"
+	"github.com/ulfurinn/amqp-dead-letter/internal/death"
+	"github.com/ulfurinn/amqp-dead-letter/internal/decode"
+	"github.com/ulfurinn/amqp-dead-letter/internal/filter"
+	"github.com/ulfurinn/amqp-dead-letter/internal/sink"
 )
 
 const (
@@ -20,9 +26,24 @@ const (
 	actionRepublishExchange
 	actionSaveToFile
 	actionDiscard
+	actionRequeue
+	actionSkip
+	actionRepublishWithBackoff
 )
 
-var errUsage = errors.New("usage: amqp-dead-letter <url> <queue>")
+var errUsage = errors.New("usage: amqp-dead-letter [--filter expr] [--action name] [--limit n] [--dry-run] [--peek] [--stream] [--prefetch n] [--sink url] [--sink-on-discard] [--max-retries n] [--retry-exchange name] <url> <queue>")
+
+// batchActionNames maps the --action flag value to an action constant,
+// for the scripted/non-interactive mode.
+var batchActionNames = map[string]int{
+	"republish-queue":        actionRepublishQueue,
+	"republish-exchange":     actionRepublishExchange,
+	"save":                   actionSaveToFile,
+	"discard":                actionDiscard,
+	"requeue":                actionRequeue,
+	"skip":                   actionSkip,
+	"republish-with-backoff": actionRepublishWithBackoff,
+}
 
 func main() {
 	if err := run(); err != nil {
@@ -32,16 +53,59 @@ func main() {
 }
 
 func run() error {
-	if len(os.Args) != 3 {
+	var (
+		filterExpr      string
+		actionName      string
+		limit           int
+		dryRun          bool
+		peek            bool
+		stream          bool
+		prefetch        int
+		sinkURL         string
+		sinkOnDiscard   bool
+		maxRetries      int
+		retryExchange   string
+		backoffBase     time.Duration
+		parkingLotQueue string
+		terminalAction  string
+		protoDescriptor string
+		protoMessage    string
+	)
+	flag.StringVar(&filterExpr, "filter", "", "expression over headers/properties selecting messages to act on")
+	flag.StringVar(&actionName, "action", "", "action to apply to matching messages: "+actionNameList())
+	flag.IntVar(&limit, "limit", 0, "stop after this many matching messages (0 = no limit)")
+	flag.BoolVar(&dryRun, "dry-run", false, "print what would happen without acting on any message")
+	flag.BoolVar(&peek, "peek", false, "leave non-matching (and, with --action skip, matching) messages unacked instead of requeueing them")
+	flag.BoolVar(&stream, "stream", false, "consume with Qos/Consume instead of one Get per message")
+	flag.IntVar(&prefetch, "prefetch", 10, "prefetch count for --stream")
+	flag.StringVar(&sinkURL, "sink", "file://.", "destination for the save action: file://dir, dir://base, jsonl://path, s3://bucket/prefix, gs://..., azblob://...")
+	flag.BoolVar(&sinkOnDiscard, "sink-on-discard", false, "also archive to the sink before discarding a message")
+	flag.IntVar(&maxRetries, "max-retries", 5, "give up on republish-with-backoff after this many x-retry-count attempts")
+	flag.StringVar(&retryExchange, "retry-exchange", "", "exchange to republish-with-backoff to (default: the exchange at the root of the x-death chain)")
+	flag.DurationVar(&backoffBase, "backoff-base", time.Second, "base delay for republish-with-backoff's exponential backoff")
+	flag.StringVar(&parkingLotQueue, "parking-lot-queue", "", "queue to route to once --max-retries is exceeded, when --terminal-action is parking-lot")
+	flag.StringVar(&terminalAction, "terminal-action", "discard", "action once --max-retries is exceeded: save|discard|parking-lot")
+	flag.StringVar(&protoDescriptor, "proto-descriptor", "", "compiled FileDescriptorSet, enables decoding application/x-protobuf payloads")
+	flag.StringVar(&protoMessage, "proto-message", "", "fully-qualified message name to decode application/x-protobuf payloads as (requires --proto-descriptor)")
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) != 2 {
 		return errUsage
 	}
 
-	url, queue := os.Args[1], os.Args[2]
-	if url == "" {
+	url, queue := args[0], args[1]
+	if url == "" || queue == "" {
 		return errUsage
 	}
-	if queue == "" {
-		return errUsage
+	if stream && (peek || actionName == "skip") {
+		// --peek and the skip action leave a delivery un-acked until the
+		// run ends. Under --stream that delivery keeps its prefetch slot
+		// forever, so once `--prefetch` messages have been peeked the
+		// consumer stalls with no further deliveries and no error. Until
+		// there's a way to periodically flush peeked deliveries, refuse
+		// the combination outright rather than hang silently.
+		return errors.New("--stream cannot be combined with --peek or --action skip (it would stall once --prefetch messages are left un-acked)")
 	}
 
 	conn, err := amqp.Dial(url)
@@ -56,35 +120,327 @@ func run() error {
 
 	ctx, _ := signal.NotifyContext(context.Background(), os.Interrupt)
 
-	return work(ctx, ch, queue)
+	var src deliverySource
+	if stream {
+		src, err = newStreamSource(ch, queue, prefetch)
+		if err != nil {
+			return err
+		}
+	} else {
+		src = newGetSource(ch, queue)
+	}
+
+	registry := decode.NewRegistry()
+	if protoDescriptor != "" || protoMessage != "" {
+		if protoDescriptor == "" || protoMessage == "" {
+			return errors.New("--proto-descriptor and --proto-message must be given together")
+		}
+		protoDec, err := decode.NewProtoDecoder(protoDescriptor, protoMessage)
+		if err != nil {
+			return err
+		}
+		registry.Register("application/x-protobuf", protoDec)
+	}
+
+	archive, err := sink.Open(sinkURL, queue, newDumper(registry))
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	rt := &runtime{
+		ch:              ch,
+		sink:            archive,
+		sinkOnDiscard:   sinkOnDiscard,
+		maxRetries:      maxRetries,
+		retryExchange:   retryExchange,
+		backoffBase:     backoffBase,
+		parkingLotQueue: parkingLotQueue,
+		terminalAction:  terminalAction,
+		decoders:        registry,
+	}
+
+	if filterExpr == "" && actionName == "" {
+		return work(ctx, rt, src)
+	}
+
+	action := actionDiscard
+	if actionName != "" {
+		a, ok := batchActionNames[actionName]
+		if !ok {
+			return fmt.Errorf("unknown --action %q, want one of %s", actionName, actionNameList())
+		}
+		action = a
+	}
+
+	expr, err := filter.Parse(filterExpr)
+	if err != nil {
+		return err
+	}
+
+	return workBatch(ctx, rt, src, batchOptions{
+		filter: expr,
+		action: action,
+		limit:  limit,
+		dryRun: dryRun,
+		peek:   peek,
+	})
+}
+
+// runtime bundles the dependencies applyAction/process need beyond the
+// delivery itself.
+type runtime struct {
+	ch            *amqp.Channel
+	sink          sink.Sink
+	sinkOnDiscard bool
+	decoders      *decode.Registry
+
+	// republish-with-backoff configuration.
+	maxRetries      int
+	retryExchange   string
+	backoffBase     time.Duration
+	parkingLotQueue string
+	terminalAction  string
+}
+
+// deliverySource abstracts where deliveries come from, so work/workBatch
+// can drive either a one-at-a-time Get loop or a Qos/Consume pipeline
+// identically.
+type deliverySource interface {
+	// receive returns the next delivery. ok is false when there is
+	// nothing left to process (queue drained, or ctx was cancelled);
+	// in that case the caller should stop and return drain()'s error.
+	receive(ctx context.Context) (del amqp.Delivery, ok bool, err error)
+	// ack records that the delivery has already been acked/nacked by
+	// the caller, so drain won't touch it.
+	ack(tag uint64)
+	// drain nacks (requeue=true) any delivery that was handed out by
+	// receive but never passed to ack - messages buffered by prefetch
+	// that Ctrl-C caught before they were acted on.
+	drain() error
+}
+
+// getSource drives the original one-Get-per-message behaviour.
+type getSource struct {
+	ch    *amqp.Channel
+	queue string
+}
+
+func newGetSource(ch *amqp.Channel, queue string) *getSource {
+	return &getSource{ch: ch, queue: queue}
+}
+
+func (s *getSource) receive(ctx context.Context) (amqp.Delivery, bool, error) {
+	select {
+	case <-ctx.Done():
+		return amqp.Delivery{}, false, nil
+	default:
+	}
+	del, ok, err := s.ch.Get(s.queue, false)
+	if err != nil {
+		return amqp.Delivery{}, false, err
+	}
+	if !ok {
+		fmt.Println("no messages left")
+		return amqp.Delivery{}, false, nil
+	}
+	return del, true, nil
+}
+
+func (s *getSource) ack(uint64)   {}
+func (s *getSource) drain() error { return nil }
+
+// streamSource drives a Qos+Consume pipeline: up to `prefetch` deliveries
+// are buffered by the broker/client ahead of being processed, so draining
+// large queues doesn't pay a network round-trip per message.
+type streamSource struct {
+	ch         *amqp.Channel
+	queue      string
+	deliveries <-chan amqp.Delivery
+	pending    map[uint64]amqp.Delivery
+}
 
+func newStreamSource(ch *amqp.Channel, queue string, prefetch int) (*streamSource, error) {
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		return nil, err
+	}
+	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &streamSource{ch: ch, queue: queue, deliveries: deliveries, pending: map[uint64]amqp.Delivery{}}, nil
 }
 
-func work(ctx context.Context, ch *amqp.Channel, queue string) error {
+func (s *streamSource) receive(ctx context.Context) (amqp.Delivery, bool, error) {
+	select {
+	case <-ctx.Done():
+		return amqp.Delivery{}, false, nil
+	case del, open := <-s.deliveries:
+		if !open {
+			fmt.Println("no messages left")
+			return amqp.Delivery{}, false, nil
+		}
+		s.pending[del.DeliveryTag] = del
+		return del, true, nil
+	default:
+	}
+
+	// Consume's delivery channel only closes when the channel/connection
+	// is torn down, not when the queue empties, so a plain blocking
+	// receive here would hang forever once the backlog is drained,
+	// unlike getSource's Get-based "no messages left". Check the
+	// broker's reported queue depth before blocking on it; if nothing's
+	// ready and nothing is already in flight, report done the same way.
+	if len(s.pending) == 0 {
+		if q, err := s.ch.QueueInspect(s.queue); err == nil && q.Messages == 0 {
+			fmt.Println("no messages left")
+			return amqp.Delivery{}, false, nil
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return amqp.Delivery{}, false, nil
+	case del, open := <-s.deliveries:
+		if !open {
+			fmt.Println("no messages left")
+			return amqp.Delivery{}, false, nil
+		}
+		s.pending[del.DeliveryTag] = del
+		return del, true, nil
+	}
+}
+
+func (s *streamSource) ack(tag uint64) {
+	delete(s.pending, tag)
+}
+
+func (s *streamSource) drain() error {
+	for tag := range s.pending {
+		if err := s.ch.Nack(tag, false, true); err != nil {
+			return err
+		}
+		delete(s.pending, tag)
+	}
+	return nil
+}
+
+func actionNameList() string {
+	names := []string{"republish-queue", "republish-exchange", "save", "discard", "requeue", "skip", "republish-with-backoff"}
+	list := names[0]
+	for _, n := range names[1:] {
+		list += "|" + n
+	}
+	return list
+}
+
+func work(ctx context.Context, rt *runtime, src deliverySource) error {
+	for {
+		del, ok, err := src.receive(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return src.drain()
+		}
+		if err := process(ctx, rt, del); err != nil {
+			return err
+		}
+		src.ack(del.DeliveryTag)
+	}
+}
+
+// batchOptions configures the non-interactive, scripted run mode driven
+// by the --filter/--action/--limit/--dry-run/--peek flags.
+type batchOptions struct {
+	filter *filter.Expr
+	action int
+	limit  int
+	dryRun bool
+	peek   bool
+}
+
+// workBatch drains queue without prompting: messages matching opts.filter
+// get opts.action applied, everything else is Nack'd with requeue=true
+// (or left unacked if opts.peek is set) so the queue is left untouched
+// for a future pass.
+func workBatch(ctx context.Context, rt *runtime, src deliverySource, opts batchOptions) error {
+	matched := 0
+	seen := 0
+	report := func() {
+		fmt.Printf("processed %d message(s), %d matched\n", seen, matched)
+	}
+
 	for {
-		select {
-		case <-ctx.Done():
-			return nil
+		if opts.limit > 0 && matched >= opts.limit {
+			fmt.Printf("limit of %d matching message(s) reached\n", opts.limit)
+			report()
+			return src.drain()
+		}
 
-		default:
-			del, ok, err := ch.Get(queue, false)
-			if err != nil {
+		del, ok, err := src.receive(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			report()
+			return src.drain()
+		}
+		seen++
+
+		matches, err := opts.filter.Eval(filter.NewEnv(del))
+		if err != nil {
+			return err
+		}
+		if !matches {
+			fmt.Printf("MESSAGE %d: no match\n", del.DeliveryTag)
+			if opts.peek {
+				continue
+			}
+			if err := del.Acknowledger.Nack(del.DeliveryTag, false, true); err != nil {
 				return err
 			}
-			if !ok {
-				fmt.Println("no messages left")
-				return nil
+			src.ack(del.DeliveryTag)
+			continue
+		}
+
+		matched++
+		fmt.Printf("MESSAGE %d: match, action=%s\n", del.DeliveryTag, actionName(opts.action))
+		if opts.dryRun {
+			if opts.peek {
+				continue
 			}
-			if err := process(ch, del); err != nil {
+			if err := del.Acknowledger.Nack(del.DeliveryTag, false, true); err != nil {
 				return err
 			}
+			src.ack(del.DeliveryTag)
+			continue
+		}
+		if opts.action == actionSkip && opts.peek {
+			continue
+		}
+
+		exchange := getHeader(del.Headers, "x-first-death-exchange")
+		srcQueue := getHeader(del.Headers, "x-first-death-queue")
+		if err := applyAction(ctx, rt, del, opts.action, exchange, srcQueue); err != nil {
+			return err
+		}
+		src.ack(del.DeliveryTag)
+	}
+}
+
+func actionName(action int) string {
+	for name, a := range batchActionNames {
+		if a == action {
+			return name
 		}
 	}
+	return "unknown"
 }
 
-func process(ch *amqp.Channel, del amqp.Delivery) error {
+func process(ctx context.Context, rt *runtime, del amqp.Delivery) error {
 	fmt.Printf("MESSAGE %d (%d remaining)\n", del.DeliveryTag, del.MessageCount)
-	printDelivery(os.Stdout, del)
+	newDumper(rt.decoders)(os.Stdout, del)
 
 	exchange := getHeader(del.Headers, "x-first-death-exchange")
 	queue := getHeader(del.Headers, "x-first-death-queue")
@@ -102,11 +458,14 @@ func process(ch *amqp.Channel, del amqp.Delivery) error {
 		actions = append(actions, actionRepublishExchange)
 	}
 
-	if del.MessageId != "" {
-		options = append(options, fmt.Sprintf("save to file %s", filename(del)))
-		actions = append(actions, actionSaveToFile)
+	if chain := death.Parse(del.Headers); len(chain) > 0 {
+		options = append(options, "republish with backoff")
+		actions = append(actions, actionRepublishWithBackoff)
 	}
 
+	options = append(options, "save")
+	actions = append(actions, actionSaveToFile)
+
 	options = append(options, "discard")
 	actions = append(actions, actionDiscard)
 
@@ -119,7 +478,15 @@ func process(ch *amqp.Channel, del amqp.Delivery) error {
 		return err
 	}
 
-	switch actions[answer] {
+	return applyAction(ctx, rt, del, actions[answer], exchange, queue)
+}
+
+// applyAction carries out action against del. exchange and queue are the
+// first-death exchange/queue discovered on the delivery, used by the
+// republish actions.
+func applyAction(ctx context.Context, rt *runtime, del amqp.Delivery, action int, exchange, queue string) error {
+	ch := rt.ch
+	switch action {
 	case actionRepublishQueue:
 		if err := ch.Publish("", queue, false, false, publishing(del)); err != nil {
 			return err
@@ -135,43 +502,159 @@ func process(ch *amqp.Channel, del amqp.Delivery) error {
 		return del.Acknowledger.Ack(del.DeliveryTag, false)
 
 	case actionSaveToFile:
-		if del.MessageId == "" {
-			return errors.New("cannot save to file without message_id")
-		}
-		f, err := os.Create(filename(del))
-		if err != nil {
-			return err
-		}
-		if err := printDelivery(f, del); err != nil {
-			return err
-		}
-		if err := f.Close(); err != nil {
+		if err := rt.sink.Write(ctx, del); err != nil {
 			return err
 		}
 		return del.Acknowledger.Ack(del.DeliveryTag, false)
 
 	case actionDiscard:
+		if rt.sinkOnDiscard {
+			if err := rt.sink.Write(ctx, del); err != nil {
+				return err
+			}
+		}
 		return del.Acknowledger.Ack(del.DeliveryTag, false)
+
+	case actionRequeue:
+		return del.Acknowledger.Nack(del.DeliveryTag, false, true)
+
+	case actionSkip:
+		return nil
+
+	case actionRepublishWithBackoff:
+		return republishWithBackoff(ctx, rt, del)
+
+	default:
+		return fmt.Errorf("unknown action %d", action)
 	}
+}
 
-	return nil
+// republishWithBackoff increments x-retry-count and republishes del with
+// a TTL computed by exponential backoff, to whatever exchange the
+// x-death chain (or --retry-exchange) says it originally came through.
+// It does not republish a chain that shows two queues dead-lettering the
+// message back and forth, since republishing would just perpetuate the
+// loop; such a message is nacked without requeue instead.
+func republishWithBackoff(ctx context.Context, rt *runtime, del amqp.Delivery) error {
+	chain := death.Parse(del.Headers)
+	if cycle, path := death.DetectPingPong(chain); cycle {
+		fmt.Printf("MESSAGE %d: refusing to republish, ping-pong death chain detected: %s\n", del.DeliveryTag, path)
+		return del.Acknowledger.Nack(del.DeliveryTag, false, false)
+	}
+
+	retryCount := getIntHeader(del.Headers, "x-retry-count") + 1
+	if retryCount > int64(rt.maxRetries) {
+		fmt.Printf("MESSAGE %d: exceeded --max-retries (%d), applying terminal action %q\n", del.DeliveryTag, rt.maxRetries, rt.terminalAction)
+		return applyTerminalAction(ctx, rt, del)
+	}
+
+	exchange := rt.retryExchange
+	if exchange == "" {
+		exchange = rootExchange(chain)
+	}
+	if exchange == "" {
+		return fmt.Errorf("message %d: no --retry-exchange given and no x-death chain to derive one from", del.DeliveryTag)
+	}
+
+	headers := amqp.Table{}
+	for k, v := range del.Headers {
+		headers[k] = v
+	}
+	headers["x-retry-count"] = retryCount
+
+	pub := publishing(del)
+	pub.Headers = headers
+	pub.Expiration = fmt.Sprintf("%d", backoffDelay(rt.backoffBase, retryCount).Milliseconds())
+
+	if err := rt.ch.Publish(exchange, del.RoutingKey, false, false, pub); err != nil {
+		return err
+	}
+	fmt.Printf("republished to %s with x-retry-count=%d, expiration=%sms\n", exchange, retryCount, pub.Expiration)
+	return del.Acknowledger.Ack(del.DeliveryTag, false)
 }
 
-func filename(del amqp.Delivery) string {
-	return fmt.Sprintf("dead-letter-%s.txt", del.MessageId)
+// applyTerminalAction is invoked once republish-with-backoff's
+// --max-retries is exceeded.
+func applyTerminalAction(ctx context.Context, rt *runtime, del amqp.Delivery) error {
+	switch rt.terminalAction {
+	case "save":
+		return applyAction(ctx, rt, del, actionSaveToFile, "", "")
+	case "parking-lot":
+		if rt.parkingLotQueue == "" {
+			return errors.New("--terminal-action parking-lot requires --parking-lot-queue")
+		}
+		return applyAction(ctx, rt, del, actionRepublishQueue, "", rt.parkingLotQueue)
+	case "discard", "":
+		return applyAction(ctx, rt, del, actionDiscard, "", "")
+	default:
+		return fmt.Errorf("unknown --terminal-action %q", rt.terminalAction)
+	}
 }
 
-func printDelivery(f *os.File, del amqp.Delivery) error {
-	if err := printProperties(f, del); err != nil {
-		return err
+// rootExchange returns the exchange at the root of the x-death chain:
+// the entry point the message originally came through, before the first
+// dead-lettering.
+func rootExchange(chain []death.Record) string {
+	if len(chain) == 0 {
+		return ""
 	}
-	if err := printHeaders(f, del); err != nil {
-		return err
+	return chain[len(chain)-1].Exchange
+}
+
+// maxBackoff caps the computed delay so a large --max-retries (operators
+// retrying for hours/days) can't overflow the exponent into garbage.
+const maxBackoff = time.Hour
+
+// backoffDelay computes an exponential backoff with jitter: roughly
+// base*2^(attempt-1), capped at maxBackoff, plus up to 50% extra so
+// retries from a burst of failures don't all land at once.
+func backoffDelay(base time.Duration, attempt int64) time.Duration {
+	if attempt < 1 {
+		attempt = 1
 	}
-	if err := printPayload(f, del); err != nil {
-		return err
+	shift := attempt - 1
+	const maxShift = 32 // base << 32 already exceeds maxBackoff for any sane base
+	if shift > maxShift {
+		shift = maxShift
+	}
+
+	d := base * time.Duration(1<<uint(shift))
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(d)/2 + 1))
+	return d + jitter
+}
+
+func getIntHeader(table amqp.Table, key string) int64 {
+	switch v := table[key].(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int:
+		return int64(v)
+	default:
+		return 0
+	}
+}
+
+// newDumper binds a decode.Registry into a sink.DumpFunc that renders a
+// delivery's properties, headers and payload as the tool's usual
+// human-readable text dump.
+func newDumper(registry *decode.Registry) sink.DumpFunc {
+	return func(w io.Writer, del amqp.Delivery) error {
+		if err := printProperties(w, del); err != nil {
+			return err
+		}
+		if err := printHeaders(w, del); err != nil {
+			return err
+		}
+		return printPayload(registry, w, del)
 	}
-	return nil
 }
 
 func publishing(del amqp.Delivery) amqp.Publishing {
@@ -210,7 +693,7 @@ type property struct {
 	Value string `header:"value"`
 }
 
-func printProperties(f *os.File, del amqp.Delivery) error {
+func printProperties(f io.Writer, del amqp.Delivery) error {
 	_, err := fmt.Fprintln(f, "PROPERTIES")
 	if err != nil {
 		return err
@@ -249,7 +732,7 @@ type header struct {
 	Value interface{} `header:"value"`
 }
 
-func printHeaders(f *os.File, del amqp.Delivery) error {
+func printHeaders(f io.Writer, del amqp.Delivery) error {
 	_, err := fmt.Fprintln(f, "HEADERS")
 	if err != nil {
 		return err
@@ -265,23 +748,16 @@ func printHeaders(f *os.File, del amqp.Delivery) error {
 	return nil
 }
 
-func printPayload(f *os.File, del amqp.Delivery) error {
+func printPayload(registry *decode.Registry, f io.Writer, del amqp.Delivery) error {
 	_, err := fmt.Fprintln(f, "PAYLOAD")
 	if err != nil {
 		return err
 	}
-	if del.ContentType == "application/json" {
-		var payload interface{}
-		if err := json.Unmarshal(del.Body, &payload); err == nil {
-			enc := json.NewEncoder(f)
-			enc.SetIndent("", "  ")
-			if err := enc.Encode(payload); err != nil {
-				return err
-			}
-		}
-		return nil
+	rendered, err := decode.Render(registry, del.ContentType, del.ContentEncoding, del.Body)
+	if err != nil {
+		return err
 	}
-	_, err = fmt.Fprintln(f, string(del.Body))
+	_, err = fmt.Fprintln(f, rendered)
 	return err
 }
 
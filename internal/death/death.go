@@ -0,0 +1,125 @@
+// Package death parses the "x-death" header RabbitMQ attaches every time
+// a message is dead-lettered, and looks for the ping-pong pattern that
+// shows up when two queues keep dead-lettering a message back and forth.
+package death
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// Record is one entry of the x-death array: one dead-lettering event.
+// RabbitMQ prepends new events, so Parse returns them newest-first.
+type Record struct {
+	Queue       string
+	Exchange    string
+	RoutingKeys []string
+	Reason      string
+	Count       int64
+	Time        time.Time
+}
+
+// Parse reads the full x-death array from headers, newest first. A
+// missing or malformed header yields an empty, non-error result.
+func Parse(headers amqp.Table) []Record {
+	raw, ok := headers["x-death"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	records := make([]Record, 0, len(raw))
+	for _, entry := range raw {
+		table, ok := entry.(amqp.Table)
+		if !ok {
+			continue
+		}
+		rec := Record{
+			Queue:    stringField(table, "queue"),
+			Exchange: stringField(table, "exchange"),
+			Reason:   stringField(table, "reason"),
+			Count:    intField(table, "count"),
+		}
+		if keys, ok := table["routing-keys"].([]interface{}); ok {
+			for _, k := range keys {
+				if s, ok := k.(string); ok {
+					rec.RoutingKeys = append(rec.RoutingKeys, s)
+				}
+			}
+		}
+		if t, ok := table["time"].(time.Time); ok {
+			rec.Time = t
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func stringField(table amqp.Table, key string) string {
+	s, _ := table[key].(string)
+	return s
+}
+
+func intField(table amqp.Table, key string) int64 {
+	switch v := table[key].(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int:
+		return int64(v)
+	default:
+		return 0
+	}
+}
+
+// DetectPingPong reports whether chain shows a message bouncing back and
+// forth between exactly two queues (the classic DLX-routes-back-to-itself
+// loop), and a human-readable rendering of the chain for the operator.
+func DetectPingPong(chain []Record) (bool, string) {
+	path := Chain(chain)
+	if len(chain) < 2 {
+		return false, path
+	}
+
+	queues := make(map[string]bool)
+	for _, r := range chain {
+		queues[r.Queue] = true
+	}
+	if len(queues) != 2 {
+		return false, path
+	}
+
+	if len(chain) == 2 {
+		// RabbitMQ collapses repeated dead-letterings through the same
+		// (queue, reason) pair into a single x-death entry, bumping its
+		// count, rather than appending a new one. So a message bouncing
+		// between exactly two queues stays a 2-entry chain forever; the
+		// tell is both counts having already incremented past their
+		// first dead-lettering.
+		return chain[0].Count > 1 && chain[1].Count > 1, path
+	}
+
+	for i := 0; i+1 < len(chain); i++ {
+		if chain[i].Queue == chain[i+1].Queue {
+			return false, path
+		}
+	}
+	return true, path
+}
+
+// Chain renders the death chain oldest-first, for printing to the operator.
+func Chain(chain []Record) string {
+	s := ""
+	for i := len(chain) - 1; i >= 0; i-- {
+		r := chain[i]
+		if s != "" {
+			s += " -> "
+		}
+		s += fmt.Sprintf("%s (via %s, reason=%s, count=%d)", r.Queue, r.Exchange, r.Reason, r.Count)
+	}
+	return s
+}
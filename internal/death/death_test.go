@@ -0,0 +1,53 @@
+package death
+
+import "testing"
+
+func TestDetectPingPongTwoEntryChain(t *testing.T) {
+	chain := []Record{
+		{Queue: "b", Count: 2},
+		{Queue: "a", Count: 2},
+	}
+	if ping, _ := DetectPingPong(chain); !ping {
+		t.Error("expected a 2-entry chain with both counts > 1 to be detected as ping-pong")
+	}
+}
+
+func TestDetectPingPongTwoEntryChainFirstPass(t *testing.T) {
+	chain := []Record{
+		{Queue: "b", Count: 1},
+		{Queue: "a", Count: 1},
+	}
+	if ping, _ := DetectPingPong(chain); ping {
+		t.Error("expected a fresh 2-entry chain (count == 1) to not be flagged as ping-pong yet")
+	}
+}
+
+func TestDetectPingPongThreeQueuesNotPingPong(t *testing.T) {
+	chain := []Record{
+		{Queue: "c", Count: 1},
+		{Queue: "b", Count: 1},
+		{Queue: "a", Count: 1},
+	}
+	if ping, _ := DetectPingPong(chain); ping {
+		t.Error("expected a chain across three distinct queues to not be ping-pong")
+	}
+}
+
+func TestDetectPingPongLongAlternatingChain(t *testing.T) {
+	chain := []Record{
+		{Queue: "a"},
+		{Queue: "b"},
+		{Queue: "a"},
+		{Queue: "b"},
+	}
+	if ping, _ := DetectPingPong(chain); !ping {
+		t.Error("expected a longer strictly-alternating two-queue chain to be ping-pong")
+	}
+}
+
+func TestDetectPingPongSingleEntry(t *testing.T) {
+	chain := []Record{{Queue: "a", Count: 5}}
+	if ping, _ := DetectPingPong(chain); ping {
+		t.Error("expected a single-entry chain to never be ping-pong")
+	}
+}
@@ -0,0 +1,135 @@
+// Package decode renders dead-lettered message bodies as readable text,
+// keyed by their ContentType, with ContentEncoding (gzip/deflate/zstd)
+// unwrapped first. Unknown content types fall back to a bounded hex dump
+// so operators never get raw bytes sprayed into their terminal.
+package decode
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Decoder renders a (already content-encoding-unwrapped) body as text.
+type Decoder interface {
+	Decode(body []byte) (string, error)
+}
+
+// DecoderFunc adapts a plain function to a Decoder.
+type DecoderFunc func(body []byte) (string, error)
+
+func (f DecoderFunc) Decode(body []byte) (string, error) { return f(body) }
+
+// Registry maps a ContentType to the Decoder that renders it.
+type Registry struct {
+	byType map[string]Decoder
+}
+
+// NewRegistry builds a registry with the built-in json/msgpack/text
+// decoders already registered.
+func NewRegistry() *Registry {
+	r := &Registry{byType: map[string]Decoder{}}
+	r.Register("application/json", DecoderFunc(decodeJSON))
+	r.Register("application/msgpack", DecoderFunc(decodeMsgpack))
+	r.Register("application/x-msgpack", DecoderFunc(decodeMsgpack))
+	return r
+}
+
+// Register associates contentType with dec, overriding any previous
+// decoder for that type.
+func (r *Registry) Register(contentType string, dec Decoder) {
+	r.byType[contentType] = dec
+}
+
+func (r *Registry) lookup(contentType string) (Decoder, bool) {
+	if dec, ok := r.byType[contentType]; ok {
+		return dec, true
+	}
+	if strings.HasPrefix(contentType, "text/") {
+		return DecoderFunc(decodeText), true
+	}
+	return nil, false
+}
+
+// hexPreviewLimit bounds how many bytes of an unrecognised payload get
+// hex-dumped, so a multi-megabyte blob doesn't flood the terminal.
+const hexPreviewLimit = 512
+
+// Render unwraps contentEncoding and renders body according to
+// contentType, falling back to a bounded hex dump for unknown types.
+func Render(registry *Registry, contentType, contentEncoding string, body []byte) (string, error) {
+	unwrapped, err := unwrap(contentEncoding, body)
+	if err != nil {
+		// A message merely claiming a Content-Encoding doesn't guarantee its
+		// body matches it; one malformed delivery shouldn't abort a batch
+		// run over the rest of the queue, so fall back to a hex preview of
+		// the still-encoded body and note why.
+		return fmt.Sprintf("(failed to unwrap content-encoding %q: %v, showing raw bytes)\n%s", contentEncoding, err, hexPreview(body)), nil
+	}
+	body = unwrapped
+
+	dec, ok := registry.lookup(contentType)
+	if !ok {
+		return hexPreview(body), nil
+	}
+	rendered, err := dec.Decode(body)
+	if err != nil {
+		// A message merely claiming a Content-Type doesn't guarantee its
+		// body matches it; one malformed delivery shouldn't abort a
+		// batch run over the rest of the queue, so fall back to a hex
+		// preview and note why.
+		return fmt.Sprintf("(failed to decode as %s: %v, showing raw bytes)\n%s", contentType, err, hexPreview(body)), nil
+	}
+	return rendered, nil
+}
+
+func unwrap(contentEncoding string, body []byte) ([]byte, error) {
+	switch contentEncoding {
+	case "", "identity":
+		return body, nil
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case "deflate":
+		r := flate.NewReader(bytes.NewReader(body))
+		defer r.Close()
+		return io.ReadAll(r)
+	case "zstd":
+		r, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	default:
+		return nil, fmt.Errorf("unsupported content-encoding %q", contentEncoding)
+	}
+}
+
+func hexPreview(body []byte) string {
+	preview := body
+	truncated := false
+	if len(preview) > hexPreviewLimit {
+		preview = preview[:hexPreviewLimit]
+		truncated = true
+	}
+	out := hex.Dump(preview)
+	if truncated {
+		out += fmt.Sprintf("... (%d more bytes)\n", len(body)-hexPreviewLimit)
+	}
+	return out
+}
+
+func decodeText(body []byte) (string, error) {
+	return string(body), nil
+}
@@ -0,0 +1,34 @@
+package decode
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func decodeJSON(body []byte) (string, error) {
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", err
+	}
+	return prettyJSON(payload)
+}
+
+func decodeMsgpack(body []byte) (string, error) {
+	var payload interface{}
+	if err := msgpack.Unmarshal(body, &payload); err != nil {
+		return "", err
+	}
+	return prettyJSON(payload)
+}
+
+func prettyJSON(payload interface{}) (string, error) {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(payload); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
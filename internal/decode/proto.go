@@ -0,0 +1,62 @@
+package decode
+
+import (
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protoDecoder renders application/x-protobuf bodies as JSON, given a
+// compiled FileDescriptorSet (`protoc -o desc.pb --include_imports ...`)
+// and the fully-qualified name of the message type the queue carries.
+type protoDecoder struct {
+	msgType protoreflect.MessageType
+}
+
+// NewProtoDecoder loads descriptorFile and looks up messageName (e.g.
+// "myapp.events.OrderPlaced") within it.
+func NewProtoDecoder(descriptorFile, messageName string) (Decoder, error) {
+	raw, err := os.ReadFile(descriptorFile)
+	if err != nil {
+		return nil, fmt.Errorf("decode: reading proto descriptor: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("decode: parsing proto descriptor: %w", err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("decode: building proto file registry: %w", err)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+	if err != nil {
+		return nil, fmt.Errorf("decode: message %q not found in descriptor: %w", messageName, err)
+	}
+	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("decode: %q is not a message type", messageName)
+	}
+
+	return &protoDecoder{msgType: dynamicpb.NewMessageType(msgDesc)}, nil
+}
+
+func (d *protoDecoder) Decode(body []byte) (string, error) {
+	msg := d.msgType.New().Interface()
+	if err := proto.Unmarshal(body, msg); err != nil {
+		return "", err
+	}
+	out, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,95 @@
+package filter
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/streadway/amqp"
+)
+
+// Env is the evaluation context for a single delivery: its headers, the
+// standard AMQP properties (addressed as property.name), and, lazily,
+// its JSON body (addressed as body.json.path.to.field).
+type Env struct {
+	del  amqp.Delivery
+	body interface{}
+	read bool
+}
+
+// NewEnv builds an Env for a delivery.
+func NewEnv(del amqp.Delivery) *Env {
+	return &Env{del: del}
+}
+
+// Header returns a table header or, absent a match, a standard property
+// of the same name (so bare identifiers like x-first-death-queue work
+// without requiring the header[...] form).
+func (e *Env) Header(key string) interface{} {
+	if v, ok := e.del.Headers[key]; ok {
+		return v
+	}
+	return e.Property(key)
+}
+
+// Property returns a standard AMQP property by name.
+func (e *Env) Property(name string) interface{} {
+	switch name {
+	case "message_id":
+		return e.del.MessageId
+	case "type":
+		return e.del.Type
+	case "routing_key":
+		return e.del.RoutingKey
+	case "content_type":
+		return e.del.ContentType
+	case "content_encoding":
+		return e.del.ContentEncoding
+	case "correlation_id":
+		return e.del.CorrelationId
+	case "reply_to":
+		return e.del.ReplyTo
+	case "expiration":
+		return e.del.Expiration
+	case "user_id":
+		return e.del.UserId
+	case "app_id":
+		return e.del.AppId
+	case "timestamp":
+		return strconv.FormatInt(e.del.Timestamp.Unix(), 10)
+	case "priority":
+		return float64(e.del.Priority)
+	default:
+		return ""
+	}
+}
+
+// BodyJSON walks path into the delivery body, parsed as JSON once and
+// cached. Returns "" if the body isn't JSON or the path doesn't resolve.
+func (e *Env) BodyJSON(path []string) interface{} {
+	if !e.read {
+		e.read = true
+		var v interface{}
+		if err := json.Unmarshal(e.del.Body, &v); err == nil {
+			e.body = v
+		}
+	}
+	cur := e.body
+	for _, seg := range path {
+		switch m := cur.(type) {
+		case map[string]interface{}:
+			cur = m[seg]
+		case []interface{}:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(m) {
+				return ""
+			}
+			cur = m[idx]
+		default:
+			return ""
+		}
+	}
+	if cur == nil {
+		return ""
+	}
+	return cur
+}
@@ -0,0 +1,226 @@
+// Package filter implements a small expression language for matching
+// dead-lettered AMQP deliveries, e.g.:
+//
+//	x-first-death-queue == "orders" && header["retry-count"] > 3
+//
+// Expressions are evaluated against an Env built from the delivery's
+// headers, standard properties and (optionally) its JSON body.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expr is a parsed, evaluatable filter expression.
+type Expr struct {
+	root node
+}
+
+// Parse compiles a filter expression. An empty string matches everything.
+func Parse(src string) (*Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return &Expr{root: boolLit(true)}, nil
+	}
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.toks) {
+		return nil, fmt.Errorf("filter: unexpected token %q", p.toks[p.pos].text)
+	}
+	return &Expr{root: n}, nil
+}
+
+// Eval runs the expression against env and returns whether it matches.
+func (e *Expr) Eval(env *Env) (bool, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("filter: expression did not evaluate to a boolean")
+	}
+	return b, nil
+}
+
+// node is a parsed AST node; eval produces a bool, string, or float64,
+// except header, property and body references, which return the raw
+// header, property or JSON value.
+type node interface {
+	eval(env *Env) (interface{}, error)
+}
+
+type boolLit bool
+
+func (b boolLit) eval(*Env) (interface{}, error) { return bool(b), nil }
+
+type stringLit string
+
+func (s stringLit) eval(*Env) (interface{}, error) { return string(s), nil }
+
+type numberLit float64
+
+func (n numberLit) eval(*Env) (interface{}, error) { return float64(n), nil }
+
+type ident struct {
+	name string
+}
+
+func (id ident) eval(env *Env) (interface{}, error) {
+	return env.Header(id.name), nil
+}
+
+type headerRef struct {
+	key string
+}
+
+func (h headerRef) eval(env *Env) (interface{}, error) {
+	return env.Header(h.key), nil
+}
+
+type propertyRef struct {
+	name string
+}
+
+func (p propertyRef) eval(env *Env) (interface{}, error) {
+	return env.Property(p.name), nil
+}
+
+type bodyPath struct {
+	path []string
+}
+
+func (b bodyPath) eval(env *Env) (interface{}, error) {
+	return env.BodyJSON(b.path), nil
+}
+
+type binOp struct {
+	op    string
+	left  node
+	right node
+}
+
+func (b binOp) eval(env *Env) (interface{}, error) {
+	switch b.op {
+	case "&&", "||":
+		l, err := b.left.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		lb, ok := l.(bool)
+		if !ok {
+			return nil, fmt.Errorf("filter: left side of %s is not boolean", b.op)
+		}
+		if b.op == "&&" && !lb {
+			return false, nil
+		}
+		if b.op == "||" && lb {
+			return true, nil
+		}
+		r, err := b.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := r.(bool)
+		if !ok {
+			return nil, fmt.Errorf("filter: right side of %s is not boolean", b.op)
+		}
+		return rb, nil
+	}
+
+	l, err := b.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := b.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b.op {
+	case "==":
+		return compareEqual(l, r), nil
+	case "!=":
+		return !compareEqual(l, r), nil
+	case "<", "<=", ">", ">=":
+		lf, lok := asNumber(l)
+		rf, rok := asNumber(r)
+		if !lok || !rok {
+			return false, nil
+		}
+		switch b.op {
+		case "<":
+			return lf < rf, nil
+		case "<=":
+			return lf <= rf, nil
+		case ">":
+			return lf > rf, nil
+		default:
+			return lf >= rf, nil
+		}
+	case "contains":
+		ls, lok := l.(string)
+		rs, rok := r.(string)
+		return lok && rok && strings.Contains(ls, rs), nil
+	case "matches":
+		ls, lok := l.(string)
+		rs, rok := r.(string)
+		if !lok || !rok {
+			return false, nil
+		}
+		re, err := regexp.Compile(rs)
+		if err != nil {
+			return false, fmt.Errorf("filter: bad regexp %q: %w", rs, err)
+		}
+		return re.MatchString(ls), nil
+	}
+	return nil, fmt.Errorf("filter: unknown operator %q", b.op)
+}
+
+func compareEqual(l, r interface{}) bool {
+	if lf, lok := asNumber(l); lok {
+		if rf, rok := asNumber(r); rok {
+			return lf == rf
+		}
+	}
+	return fmt.Sprint(l) == fmt.Sprint(r)
+}
+
+// asNumber mirrors AMQP table numeric coercion (int32/int64/float64) so
+// that `header["retry-count"] > 3` works regardless of which integer
+// width the broker sent.
+func asNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0, false
+		}
+		return f, true
+	default:
+		return 0, false
+	}
+}
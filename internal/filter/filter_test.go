@@ -0,0 +1,65 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/streadway/amqp"
+)
+
+func eval(t *testing.T, expr string, headers amqp.Table) bool {
+	t.Helper()
+	e, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", expr, err)
+	}
+	ok, err := e.Eval(NewEnv(amqp.Delivery{Headers: headers}))
+	if err != nil {
+		t.Fatalf("Eval(%q): %v", expr, err)
+	}
+	return ok
+}
+
+func TestNegativeNumberLiteral(t *testing.T) {
+	headers := amqp.Table{"retry-count": int32(-1)}
+	if !eval(t, `header["retry-count"] == -1`, headers) {
+		t.Error("expected retry-count == -1 to match")
+	}
+	if eval(t, `header["retry-count"] > -1`, headers) {
+		t.Error("expected retry-count > -1 to not match")
+	}
+	if !eval(t, `header["retry-count"] > -3`, headers) {
+		t.Error("expected retry-count > -3 to match")
+	}
+}
+
+func TestParseRejectsUnknownCharacter(t *testing.T) {
+	if _, err := Parse(`header["x"] == 1 @ 2`); err == nil {
+		t.Error("expected an error for an unrecognised character, got nil")
+	}
+}
+
+func TestEmptyExpressionMatchesEverything(t *testing.T) {
+	if !eval(t, ``, nil) {
+		t.Error("expected empty filter to match")
+	}
+}
+
+func TestAndOr(t *testing.T) {
+	headers := amqp.Table{"x-first-death-queue": "orders", "retry-count": int32(4)}
+	if !eval(t, `header["x-first-death-queue"] == "orders" && header["retry-count"] > 3`, headers) {
+		t.Error("expected conjunction to match")
+	}
+	if eval(t, `header["x-first-death-queue"] == "shipping" || header["retry-count"] <= 3`, headers) {
+		t.Error("expected disjunction to not match")
+	}
+}
+
+func TestContainsAndMatches(t *testing.T) {
+	headers := amqp.Table{"reason": "connection refused"}
+	if !eval(t, `header["reason"] contains "refused"`, headers) {
+		t.Error("expected contains to match")
+	}
+	if !eval(t, `header["reason"] matches "^connection"`, headers) {
+		t.Error("expected regexp match")
+	}
+}
@@ -0,0 +1,271 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokDot
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	r := []rune(src)
+	i := 0
+	for i < len(r) {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "("})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")"})
+			i++
+		case c == '[':
+			toks = append(toks, token{tokLBracket, "["})
+			i++
+		case c == ']':
+			toks = append(toks, token{tokRBracket, "]"})
+			i++
+		case c == '.':
+			toks = append(toks, token{tokDot, "."})
+			i++
+		case c == '"':
+			j := i + 1
+			var sb strings.Builder
+			for j < len(r) && r[j] != '"' {
+				sb.WriteRune(r[j])
+				j++
+			}
+			toks = append(toks, token{tokString, sb.String()})
+			i = j + 1
+		case strings.ContainsRune("=!<>&|", c):
+			op := string(c)
+			if i+1 < len(r) && r[i+1] == '=' && (c == '=' || c == '!' || c == '<' || c == '>') {
+				op += "="
+				i += 2
+			} else if i+1 < len(r) && r[i+1] == c && (c == '&' || c == '|') {
+				op += string(c)
+				i += 2
+			} else {
+				i++
+			}
+			toks = append(toks, token{tokOp, op})
+		case c == '-' && i+1 < len(r) && unicode.IsDigit(r[i+1]):
+			j := i + 1
+			for j < len(r) && (unicode.IsDigit(r[j]) || r[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, string(r[i:j])})
+			i = j
+		case unicode.IsDigit(c):
+			j := i
+			for j < len(r) && (unicode.IsDigit(r[j]) || r[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, string(r[i:j])})
+			i = j
+		case isIdentStart(c):
+			j := i
+			for j < len(r) && isIdentPart(r[j]) {
+				j++
+			}
+			toks = append(toks, token{tokIdent, string(r[i:j])})
+			i = j
+		default:
+			return nil, fmt.Errorf("filter: unexpected character %q", c)
+		}
+	}
+	return toks, nil
+}
+
+func isIdentStart(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
+}
+
+func isIdentPart(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '-'
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token {
+	if p.pos >= len(p.toks) {
+		return token{kind: tokEOF}
+	}
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	t := p.next()
+	if t.kind != kind || (text != "" && t.text != text) {
+		return fmt.Errorf("filter: expected %q, got %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binOp{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseCompare()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseCompare()
+		if err != nil {
+			return nil, err
+		}
+		left = binOp{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+var compareOps = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind == tokOp && compareOps[t.text] {
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return binOp{op: t.text, left: left, right: right}, nil
+	}
+	if t.kind == tokIdent && (t.text == "contains" || t.text == "matches") {
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return binOp{op: t.text, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokLParen:
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokRParen, ")"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case t.kind == tokString:
+		p.next()
+		return stringLit(t.text), nil
+	case t.kind == tokNumber:
+		p.next()
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("filter: bad number %q", t.text)
+		}
+		return numberLit(f), nil
+	case t.kind == tokIdent:
+		return p.parseIdentChain()
+	default:
+		return nil, fmt.Errorf("filter: unexpected token %q", t.text)
+	}
+}
+
+func (p *parser) parseIdentChain() (node, error) {
+	t := p.next()
+
+	if t.text == "header" && p.peek().kind == tokLBracket {
+		p.next()
+		key := p.next()
+		if key.kind != tokString {
+			return nil, fmt.Errorf("filter: header[] requires a string key")
+		}
+		if err := p.expect(tokRBracket, "]"); err != nil {
+			return nil, err
+		}
+		return headerRef{key: key.text}, nil
+	}
+
+	if t.text == "property" && p.peek().kind == tokDot {
+		p.next()
+		name := p.next()
+		if name.kind != tokIdent {
+			return nil, fmt.Errorf("filter: property. requires a field name")
+		}
+		return propertyRef{name: name.text}, nil
+	}
+
+	if t.text == "body" && p.peek().kind == tokDot {
+		p.next()
+		kind := p.next()
+		if kind.text != "json" {
+			return nil, fmt.Errorf("filter: body. requires a json path, got %q", kind.text)
+		}
+		var path []string
+		for p.peek().kind == tokDot {
+			p.next()
+			seg := p.next()
+			if seg.kind != tokIdent && seg.kind != tokNumber {
+				return nil, fmt.Errorf("filter: bad body.json path segment %q", seg.text)
+			}
+			path = append(path, seg.text)
+		}
+		return bodyPath{path: path}, nil
+	}
+
+	return ident{name: t.text}, nil
+}
@@ -0,0 +1,63 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/streadway/amqp"
+	"gocloud.dev/blob"
+
+	// Side-effect imports register the s3://, gs:// and azblob:// URL
+	// schemes with blob.OpenBucket.
+	_ "gocloud.dev/blob/azureblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+// blobSink writes one object per message to an S3/GCS/Azure bucket,
+// keyed by <queue>/<key>.txt, where <key> is what key() derives.
+type blobSink struct {
+	bucket *blob.Bucket
+	queue  string
+	dump   DumpFunc
+}
+
+func newBlobSink(u *url.URL, queue string, dump DumpFunc) (*blobSink, error) {
+	ctx := context.Background()
+	bucket, err := blob.OpenBucket(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// OpenBucket only looks at u.Host for the bucket/container name and
+	// ignores the path, so s3://bucket/prefix would otherwise silently
+	// drop the /prefix. Wrapping in a prefixed view is what actually
+	// makes the documented URL form honour it.
+	if prefix := blobPrefix(u); prefix != "" {
+		bucket = blob.PrefixedBucket(bucket, prefix+"/")
+	}
+
+	return &blobSink{bucket: bucket, queue: queue, dump: dump}, nil
+}
+
+// blobPrefix extracts the path segment of a s3/gs/azblob URL (the part
+// after the bucket name) to use as the key prefix, e.g. "prefix" out of
+// "s3://bucket/prefix". Split out so the path math can be unit-tested
+// without opening a real bucket.
+func blobPrefix(u *url.URL) string {
+	return strings.Trim(u.Path, "/")
+}
+
+func (s *blobSink) Write(ctx context.Context, del amqp.Delivery) error {
+	var buf bytes.Buffer
+	if err := s.dump(&buf, del); err != nil {
+		return err
+	}
+	return s.bucket.WriteAll(ctx, s.queue+"/"+key(del)+".txt", buf.Bytes(), nil)
+}
+
+func (s *blobSink) Close() error {
+	return s.bucket.Close()
+}
@@ -0,0 +1,75 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/streadway/amqp"
+)
+
+// fileSink writes one file per message into a flat directory, matching
+// the tool's original dead-letter-<message_id>.txt behaviour.
+type fileSink struct {
+	dir  string
+	dump DumpFunc
+}
+
+func newFileSink(dir string, dump DumpFunc) (*fileSink, error) {
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileSink{dir: dir, dump: dump}, nil
+}
+
+func (s *fileSink) Write(_ context.Context, del amqp.Delivery) error {
+	path := filepath.Join(s.dir, fmt.Sprintf("dead-letter-%s.txt", key(del)))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := s.dump(f, del); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *fileSink) Close() error { return nil }
+
+// dirSink nests one file per message under a directory named after the
+// source queue, keyed by key() so repeated runs don't collide.
+type dirSink struct {
+	dir  string
+	dump DumpFunc
+}
+
+func newDirSink(base, queue string, dump DumpFunc) (*dirSink, error) {
+	if base == "" {
+		base = "."
+	}
+	dir := filepath.Join(base, queue)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &dirSink{dir: dir, dump: dump}, nil
+}
+
+func (s *dirSink) Write(_ context.Context, del amqp.Delivery) error {
+	path := filepath.Join(s.dir, key(del)+".txt")
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := s.dump(f, del); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *dirSink) Close() error { return nil }
@@ -0,0 +1,66 @@
+package sink
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"unicode/utf8"
+
+	"github.com/streadway/amqp"
+)
+
+// jsonlRecord is one archived delivery: headers verbatim, the properties
+// listed below (timestamp as Unix seconds), and the body either inlined
+// (valid UTF-8) or base64-encoded. Other properties are not recorded.
+type jsonlRecord struct {
+	MessageID       string                 `json:"message_id,omitempty"`
+	Type            string                 `json:"type,omitempty"`
+	RoutingKey      string                 `json:"routing_key,omitempty"`
+	ContentType     string                 `json:"content_type,omitempty"`
+	ContentEncoding string                 `json:"content_encoding,omitempty"`
+	CorrelationID   string                 `json:"correlation_id,omitempty"`
+	Timestamp       int64                  `json:"timestamp,omitempty"`
+	Headers         map[string]interface{} `json:"headers,omitempty"`
+	Body            string                 `json:"body,omitempty"`
+	BodyBase64      string                 `json:"body_base64,omitempty"`
+}
+
+// jsonlSink appends one JSON object per delivery to a single file.
+type jsonlSink struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+func newJSONLSink(path string) (*jsonlSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonlSink{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (s *jsonlSink) Write(_ context.Context, del amqp.Delivery) error {
+	rec := jsonlRecord{
+		MessageID:       del.MessageId,
+		Type:            del.Type,
+		RoutingKey:      del.RoutingKey,
+		ContentType:     del.ContentType,
+		ContentEncoding: del.ContentEncoding,
+		CorrelationID:   del.CorrelationId,
+		Headers:         del.Headers,
+	}
+	if !del.Timestamp.IsZero() {
+		rec.Timestamp = del.Timestamp.Unix()
+	}
+	if utf8.Valid(del.Body) {
+		rec.Body = string(del.Body)
+	} else {
+		rec.BodyBase64 = base64.StdEncoding.EncodeToString(del.Body)
+	}
+	return s.enc.Encode(rec)
+}
+
+func (s *jsonlSink) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,22 @@
+package sink
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/streadway/amqp"
+)
+
+func TestKeySanitizesMessageID(t *testing.T) {
+	del := amqp.Delivery{MessageId: "../../../../tmp/pwned"}
+	got := key(del)
+	if got != filepath.Base(got) {
+		t.Fatalf("key(%q) = %q, still contains a path separator", del.MessageId, got)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dead-letter-"+got+".txt")
+	if filepath.Dir(path) != dir {
+		t.Fatalf("dead-letter-%s.txt escaped %s: resolved to %s", got, dir, path)
+	}
+}
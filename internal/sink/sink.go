@@ -0,0 +1,105 @@
+// Package sink implements pluggable destinations for archiving
+// dead-lettered deliveries, selected by URL scheme (in the spirit of
+// go-cloud's "one URL, many backends"):
+//
+//	file://./dumps        one dead-letter-<message_id>.txt per message (default)
+//	dir://./archive        one file per message, nested under <queue>/
+//	jsonl://path.jsonl     one JSON object per line, appended
+//	s3://bucket/prefix     blob storage, one object per message
+//	gs://bucket/prefix     blob storage, one object per message
+//	azblob://bucket/prefix blob storage, one object per message
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// Sink is a destination deliveries can be archived to.
+type Sink interface {
+	Write(ctx context.Context, del amqp.Delivery) error
+	Close() error
+}
+
+// DumpFunc renders a delivery as the human-readable properties/headers/
+// payload dump main.go already produces for the single-file case. The
+// file, dir and blob backends use it so archived messages keep the same
+// format operators see on screen.
+type DumpFunc func(w io.Writer, del amqp.Delivery) error
+
+// Open parses rawURL and returns the Sink it selects. queue is the
+// source queue the sink was opened for, used by layouts that key on it.
+// dump renders a delivery for the text-based backends (file, dir, blob);
+// jsonl has its own structured encoding and ignores it.
+func Open(rawURL, queue string, dump DumpFunc) (Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("sink: bad URL %q: %w", rawURL, err)
+	}
+
+	switch u.Scheme {
+	case "", "file":
+		return newFileSink(filePath(u), dump)
+	case "dir":
+		return newDirSink(filePath(u), queue, dump)
+	case "jsonl":
+		return newJSONLSink(filePath(u))
+	case "s3", "gs", "azblob":
+		return newBlobSink(u, queue, dump)
+	default:
+		return nil, fmt.Errorf("sink: unknown scheme %q", u.Scheme)
+	}
+}
+
+// filePath reconstructs the filesystem path the user wrote after
+// "scheme://" or "scheme:". net/url parses the authority form
+// (scheme://...) by splitting off the first path segment into u.Host, so
+// a doc-comment example like "file://./dumps" would otherwise resolve to
+// the absolute path "/dumps" and "jsonl://path.jsonl" would drop the
+// filename entirely. u.Opaque covers the "scheme:path" form without a
+// "//" authority.
+func filePath(u *url.URL) string {
+	if u.Opaque != "" {
+		return u.Opaque
+	}
+	return u.Host + u.Path
+}
+
+// processStart and seq back the no-message-id fallback in key(): both
+// DeliveryTag (restarts at 1 per channel) and Timestamp (an optional,
+// producer-supplied AMQP property, very often left unset) are too weak
+// to key a collision-resistant filename on, so a message without a
+// message_id gets named from this process's start time plus a
+// monotonically increasing counter instead.
+var processStart = time.Now()
+var seq uint64
+
+// key derives a stable, collision-resistant name for a delivery: its
+// message_id when present, otherwise a name unique to this process run
+// so two runs (or two messages within a run) never collide.
+func key(del amqp.Delivery) string {
+	if del.MessageId != "" {
+		return sanitizeKey(del.MessageId)
+	}
+	return fmt.Sprintf("noid-%d-%d", processStart.UnixNano(), atomic.AddUint64(&seq, 1))
+}
+
+// sanitizeKey replaces path separators with "_" in a producer-supplied
+// message_id before it's used as a filename or blob key. message_id is
+// producer-controlled, and every caller of key() joins the result into
+// a path (filepath.Join for file/dir, string concatenation for blob),
+// so a message_id like "../../../../tmp/pwned" would otherwise let
+// --action save (or --sink-on-discard) write outside the sink's
+// directory.
+func sanitizeKey(id string) string {
+	id = strings.ReplaceAll(id, "/", "_")
+	id = strings.ReplaceAll(id, "\\", "_")
+	return id
+}